@@ -0,0 +1,83 @@
+package filepermissions
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Access describes the set of HTTP methods a PathGrant allows for the
+// files under its Path. Use NewAccess to build a custom method set, or one
+// of the predefined shorthands (Read, Append, ReadWrite, Admin) for the
+// common cases.
+type Access struct {
+	methods map[string]struct{}
+}
+
+// NewAccess builds an Access that permits exactly the given HTTP methods,
+// e.g. NewAccess(http.MethodGet, http.MethodPost).
+func NewAccess(methods ...string) Access {
+	set := make(map[string]struct{}, len(methods))
+	for _, m := range methods {
+		set[m] = struct{}{}
+	}
+	return Access{methods: set}
+}
+
+// allows reports whether the access mode permits the given HTTP method.
+func (a Access) allows(method string) bool {
+	_, ok := a.methods[method]
+	return ok
+}
+
+var (
+	// Read allows GET requests only.
+	Read = NewAccess(http.MethodGet)
+	// Append allows GET and POST, for policies that can add files but
+	// never modify or remove existing ones.
+	Append = NewAccess(http.MethodGet, http.MethodPost)
+	// ReadWrite allows GET, POST, PUT, PATCH, and DELETE. Kept equivalent
+	// to the original Read/ReadWrite binary for backward compatibility.
+	ReadWrite = NewAccess(http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete)
+	// Admin allows every HTTP method, including DELETE and the less
+	// common verbs ReadWrite omits (HEAD, OPTIONS, CONNECT, TRACE).
+	Admin = NewAccess(
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodPost,
+		http.MethodPut,
+		http.MethodPatch,
+		http.MethodDelete,
+		http.MethodConnect,
+		http.MethodOptions,
+		http.MethodTrace,
+	)
+)
+
+// allowedMethods returns the sorted, de-duplicated union of HTTP methods
+// permitted by the allow grants in matches, for reporting in an
+// access-denied error payload. Deny grants are excluded since they never
+// grant access, and a method is left out when a deny grant wins over the
+// allow grants for it under evaluateAccess.
+func allowedMethods(matches []matchedGrant) []string {
+	set := make(map[string]struct{})
+	for _, m := range matches {
+		if m.grant.Deny {
+			continue
+		}
+		for method := range m.grant.Access.methods {
+			set[method] = struct{}{}
+		}
+	}
+
+	methods := make([]string, 0, len(set))
+	for method := range set {
+		if allowed, _ := evaluateAccess(matches, method); allowed {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,230 @@
+package filepermissions
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL is used when CacheOptions.TTL is left zero.
+const defaultCacheTTL = time.Minute
+
+// MetricsHook observes grant cache activity. Implement it to export cache
+// hit/miss counters to your metrics system of choice.
+type MetricsHook interface {
+	CacheHit(key string)
+	CacheMiss(key string)
+}
+
+// CacheOptions configures CreateFilePermissionsMiddlewareWithCache.
+type CacheOptions struct {
+	// TTL is how long a cached []PathGrant stays valid. Defaults to one
+	// minute.
+	TTL time.Duration
+	// MaxEntries bounds the cache size; the least recently used entry is
+	// evicted once it's exceeded. Zero means unbounded.
+	MaxEntries int
+	// KeyFunc derives a cache key from the request, and should identify
+	// the requester the same way helpers.GetUserGrants does. Defaults to
+	// the request's Authorization header, falling back to RemoteAddr.
+	KeyFunc func(r *http.Request) string
+	// Metrics, if set, is notified of every cache hit and miss.
+	Metrics MetricsHook
+}
+
+func defaultCacheKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return auth
+	}
+	return r.RemoteAddr
+}
+
+// CachedMiddleware is a file-permissions middleware backed by a grant
+// cache. Build one with CreateFilePermissionsMiddlewareWithCache.
+type CachedMiddleware struct {
+	inner   Helpers
+	cache   *grantCache
+	keyFunc func(r *http.Request) string
+	opts    []Option
+}
+
+// CreateFilePermissionsMiddlewareWithCache wraps helpers so that
+// GetUserGrants results are memoized per CacheOptions.KeyFunc, avoiding a
+// round-trip to helpers on every request. Call Wrap to get the
+// http.Handler middleware, and Invalidate when a user's permissions
+// change so stale grants aren't served from the cache.
+func CreateFilePermissionsMiddlewareWithCache(helpers Helpers, cacheOpts CacheOptions, opts ...Option) *CachedMiddleware {
+	keyFunc := cacheOpts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = defaultCacheKey
+	}
+	ttl := cacheOpts.TTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	return &CachedMiddleware{
+		inner:   helpers,
+		cache:   newGrantCache(ttl, cacheOpts.MaxEntries, cacheOpts.Metrics),
+		keyFunc: keyFunc,
+		opts:    opts,
+	}
+}
+
+// Wrap builds the http.Handler middleware, just like the func returned by
+// CreateFilePermissionsMiddleware.
+func (m *CachedMiddleware) Wrap(next http.Handler) http.Handler {
+	cached := cachingHelpers{inner: m.inner, cache: m.cache, keyFunc: m.keyFunc}
+	return CreateFilePermissionsMiddleware(cached, m.opts...)(next)
+}
+
+// Invalidate evicts the cached grants for key, so the next matching
+// request re-fetches them from the wrapped Helpers.
+func (m *CachedMiddleware) Invalidate(key string) {
+	m.cache.invalidate(key)
+}
+
+// cachingHelpers adapts a Helpers so that GetUserGrants is served from a
+// grantCache instead of calling through on every request.
+type cachingHelpers struct {
+	inner   Helpers
+	cache   *grantCache
+	keyFunc func(r *http.Request) string
+}
+
+func (h cachingHelpers) GetUserGrants(r *http.Request) ([]PathGrant, error) {
+	key := h.keyFunc(r)
+	return h.cache.getOrLoad(key, func() ([]PathGrant, error) {
+		return h.inner.GetUserGrants(r)
+	})
+}
+
+func (h cachingHelpers) GetRequestedPath(r *http.Request) (string, error) {
+	return h.inner.GetRequestedPath(r)
+}
+
+// cacheEntry is a cached GetUserGrants result.
+type cacheEntry struct {
+	grants    []PathGrant
+	expiresAt time.Time
+}
+
+// inflightCall lets concurrent getOrLoad calls for the same key share a
+// single underlying load, singleflight-style.
+type inflightCall struct {
+	wg     sync.WaitGroup
+	grants []PathGrant
+	err    error
+}
+
+// grantCache is an LRU cache of []PathGrant with a per-entry TTL and
+// in-flight call de-duplication. Errors from the loader are never cached.
+type grantCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	metrics    MetricsHook
+
+	order    *list.List // front = most recently used
+	elements map[string]*list.Element
+	inflight map[string]*inflightCall
+}
+
+type cacheElement struct {
+	key   string
+	entry cacheEntry
+}
+
+func newGrantCache(ttl time.Duration, maxEntries int, metrics MetricsHook) *grantCache {
+	return &grantCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		metrics:    metrics,
+		order:      list.New(),
+		elements:   make(map[string]*list.Element),
+		inflight:   make(map[string]*inflightCall),
+	}
+}
+
+// getOrLoad returns the cached grants for key, or calls load once -
+// regardless of how many goroutines request key concurrently - and caches
+// the result if it succeeds.
+func (c *grantCache) getOrLoad(key string, load func() ([]PathGrant, error)) ([]PathGrant, error) {
+	c.mu.Lock()
+	if el, ok := c.elements[key]; ok {
+		entry := el.Value.(*cacheElement).entry
+		if time.Now().Before(entry.expiresAt) {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			c.recordHit(key)
+			return entry.grants, nil
+		}
+		c.removeElement(el)
+	}
+
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		call.wg.Wait()
+		return call.grants, call.err
+	}
+
+	call := &inflightCall{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	c.recordMiss(key)
+	call.grants, call.err = load()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.put(key, call.grants)
+	}
+	c.mu.Unlock()
+
+	call.wg.Done()
+	return call.grants, call.err
+}
+
+func (c *grantCache) put(key string, grants []PathGrant) {
+	entry := cacheEntry{grants: grants, expiresAt: time.Now().Add(c.ttl)}
+	if el, ok := c.elements[key]; ok {
+		el.Value.(*cacheElement).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&cacheElement{key: key, entry: entry})
+	c.elements[key] = el
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *grantCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.elements[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// removeElement must be called with c.mu held.
+func (c *grantCache) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.elements, el.Value.(*cacheElement).key)
+}
+
+func (c *grantCache) recordHit(key string) {
+	if c.metrics != nil {
+		c.metrics.CacheHit(key)
+	}
+}
+
+func (c *grantCache) recordMiss(key string) {
+	if c.metrics != nil {
+		c.metrics.CacheMiss(key)
+	}
+}
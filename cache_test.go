@@ -0,0 +1,187 @@
+package filepermissions
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// funcHelpers adapts plain functions to the Helpers interface for tests
+// that don't fit mockHelpers' context-keyed users.
+type funcHelpers struct {
+	getUserGrants    func(r *http.Request) ([]PathGrant, error)
+	getRequestedPath func(r *http.Request) (string, error)
+}
+
+func (f funcHelpers) GetUserGrants(r *http.Request) ([]PathGrant, error) {
+	return f.getUserGrants(r)
+}
+
+func (f funcHelpers) GetRequestedPath(r *http.Request) (string, error) {
+	return f.getRequestedPath(r)
+}
+
+func TestGrantCacheDeduplicatesConcurrentLoads(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newGrantCache(time.Minute, 0, nil)
+	var calls int32
+	ready := make(chan struct{})
+	load := func() ([]PathGrant, error) {
+		atomic.AddInt32(&calls, 1)
+		<-ready
+		return []PathGrant{{Access: Read, Path: "/a/"}}, nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			grants, err := cache.getOrLoad("user-1", load)
+			assert.NoError(err)
+			assert.Len(grants, 1)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond) // let every goroutine reach the blocking load
+	close(ready)
+	wg.Wait()
+
+	assert.EqualValues(1, atomic.LoadInt32(&calls), "concurrent requests for the same key should share a single load")
+}
+
+func TestGrantCacheDoesNotCacheErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newGrantCache(time.Minute, 0, nil)
+	calls := 0
+	load := func() ([]PathGrant, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("boom")
+		}
+		return []PathGrant{{Access: Read, Path: "/a/"}}, nil
+	}
+
+	_, err := cache.getOrLoad("user-1", load)
+	assert.Error(err)
+
+	grants, err := cache.getOrLoad("user-1", load)
+	assert.NoError(err)
+	assert.Len(grants, 1)
+	assert.Equal(2, calls, "an error result should not be cached")
+}
+
+func TestGrantCacheExpiresAfterTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newGrantCache(10*time.Millisecond, 0, nil)
+	calls := 0
+	load := func() ([]PathGrant, error) {
+		calls++
+		return []PathGrant{{Access: Read, Path: "/a/"}}, nil
+	}
+
+	_, err := cache.getOrLoad("user-1", load)
+	assert.NoError(err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = cache.getOrLoad("user-1", load)
+	assert.NoError(err)
+
+	assert.Equal(2, calls, "an expired entry should be reloaded")
+}
+
+func TestGrantCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newGrantCache(time.Minute, 2, nil)
+	calls := map[string]int{}
+	loadFor := func(key string) func() ([]PathGrant, error) {
+		return func() ([]PathGrant, error) {
+			calls[key]++
+			return []PathGrant{{Access: Read, Path: "/" + key + "/"}}, nil
+		}
+	}
+
+	cache.getOrLoad("a", loadFor("a"))
+	cache.getOrLoad("b", loadFor("b"))
+	cache.getOrLoad("c", loadFor("c")) // evicts "a", the least recently used
+
+	_, err := cache.getOrLoad("a", loadFor("a"))
+	assert.NoError(err)
+
+	assert.Equal(2, calls["a"], "evicted key should be reloaded")
+	assert.Equal(1, calls["b"])
+	assert.Equal(1, calls["c"])
+}
+
+type recordingMetrics struct {
+	mu     sync.Mutex
+	hits   []string
+	misses []string
+}
+
+func (m *recordingMetrics) CacheHit(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hits = append(m.hits, key)
+}
+
+func (m *recordingMetrics) CacheMiss(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.misses = append(m.misses, key)
+}
+
+func TestGrantCacheRecordsHitsAndMisses(t *testing.T) {
+	assert := assert.New(t)
+
+	metrics := &recordingMetrics{}
+	cache := newGrantCache(time.Minute, 0, metrics)
+	load := func() ([]PathGrant, error) {
+		return []PathGrant{{Access: Read, Path: "/a/"}}, nil
+	}
+
+	cache.getOrLoad("user-1", load)
+	cache.getOrLoad("user-1", load)
+
+	assert.Equal([]string{"user-1"}, metrics.misses)
+	assert.Equal([]string{"user-1"}, metrics.hits)
+}
+
+func TestCachedMiddlewareInvalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	helpers := funcHelpers{
+		getUserGrants: func(r *http.Request) ([]PathGrant, error) {
+			atomic.AddInt32(&calls, 1)
+			return ShippingGrants, nil
+		},
+		getRequestedPath: func(r *http.Request) (string, error) {
+			return r.URL.Path, nil
+		},
+	}
+
+	mw := CreateFilePermissionsMiddlewareWithCache(helpers, CacheOptions{
+		KeyFunc: func(r *http.Request) string { return "worker" },
+	})
+	hdlr := mw.Wrap(defaultHandler{})
+
+	req := httptest.NewRequest("GET", "http://testing.com/hr/shipping/instructions.pdf", nil)
+	hdlr.ServeHTTP(httptest.NewRecorder(), req)
+	hdlr.ServeHTTP(httptest.NewRecorder(), req)
+	assert.EqualValues(1, atomic.LoadInt32(&calls), "the second request should be served from the cache")
+
+	mw.Invalidate("worker")
+	hdlr.ServeHTTP(httptest.NewRecorder(), req)
+	assert.EqualValues(2, atomic.LoadInt32(&calls), "invalidating the key should force a fresh load")
+}
@@ -0,0 +1,64 @@
+package filepermissions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenyPrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name        string
+		grants      []PathGrant
+		path        string
+		wantAllowed bool
+	}{
+		{
+			name: "deny at the same depth as the allow wins the tie",
+			grants: []PathGrant{
+				{Access: Read, Path: "/hr/shipping/"},
+				{Access: Read, Path: "/hr/shipping/", Deny: true},
+			},
+			path:        "/hr/shipping/instructions.pdf",
+			wantAllowed: false,
+		},
+		{
+			name: "a deeper, more specific deny overrides a broader allow",
+			grants: []PathGrant{
+				{Access: Read, Path: "/hr/"},
+				{Access: Read, Path: "/hr/salaries/", Deny: true},
+			},
+			path:        "/hr/salaries/q3.pdf",
+			wantAllowed: false,
+		},
+		{
+			name: "a deeper, more specific allow overrides a broader deny",
+			grants: []PathGrant{
+				{Access: Read, Path: "/hr/", Deny: true},
+				{Access: Read, Path: "/hr/shipping/"},
+			},
+			path:        "/hr/shipping/instructions.pdf",
+			wantAllowed: true,
+		},
+		{
+			name: "an allow elsewhere in the tree is unaffected by an unrelated deny",
+			grants: []PathGrant{
+				{Access: Read, Path: "/hr/salaries/", Deny: true},
+				{Access: Read, Path: "/hr/shipping/"},
+			},
+			path:        "/hr/shipping/instructions.pdf",
+			wantAllowed: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches := matchGrants(tc.grants, tc.path)
+			allowed, _ := evaluateAccess(matches, http.MethodGet)
+			assert.Equal(tc.wantAllowed, allowed)
+		})
+	}
+}
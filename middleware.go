@@ -0,0 +1,169 @@
+// Package filepermissions implements an http.Handler middleware that
+// enforces per-user, per-path file access grants before letting a request
+// reach the wrapped handler.
+package filepermissions
+
+import (
+	"context"
+	"net/http"
+)
+
+// PathGrant authorizes (or, with Deny set, forbids) Access to every file
+// under Path. Path may be a directory-style prefix (e.g. "/hr/shipping/")
+// or a pattern containing named parameters and wildcards; see pattern.go
+// for the supported syntax.
+//
+// When two grants from the same Helpers.GetUserGrants call match a
+// request's path and method, and one is a Deny, the more specific of the
+// two wins; a tie between an allow and a deny is resolved in the deny's
+// favor. See evaluateAccess for the precise precedence rules.
+type PathGrant struct {
+	Access Access
+	Path   string
+	// Deny, when true, makes this grant forbid rather than permit Access
+	// for requests matching Path, carving out an exception within a
+	// broader allow.
+	Deny bool
+}
+
+// Error is returned by a Helpers method to signal that the middleware
+// should respond with a specific HTTP status code instead of the default.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Helpers supplies the two pieces of per-request information the
+// middleware needs: who the requester is (via the grants they hold) and
+// what file path they're trying to reach.
+type Helpers interface {
+	// GetUserGrants returns the PathGrants held by the requester. Returning
+	// an *Error causes the middleware to respond with its Code; any other
+	// error results in a 500.
+	GetUserGrants(r *http.Request) ([]PathGrant, error)
+	// GetRequestedPath returns the file path the request is targeting.
+	GetRequestedPath(r *http.Request) (string, error)
+}
+
+// CreateFilePermissionsMiddleware builds a middleware that authorizes each
+// request against the PathGrants returned by helpers before invoking the
+// next handler. A request is rejected with 401 unless an allow grant for
+// its path and method wins under evaluateAccess. If helpers returns an
+// *Error, the response carries its Code; any other helpers failure, or an
+// empty set of grants, results in a 500.
+// Errors are rendered by the ErrorResponder configured via WithErrorResponder,
+// which defaults to a JSON/XML/plain-text negotiating responder.
+func CreateFilePermissionsMiddleware(helpers Helpers, opts ...Option) func(http.Handler) http.Handler {
+	cfg := newConfig(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			grants, err := helpers.GetUserGrants(r)
+			if err != nil {
+				code, message := errorDetails(err)
+				cfg.responder.RespondError(w, r, ErrorInfo{Code: code, Message: message})
+				return
+			}
+			if len(grants) == 0 {
+				cfg.responder.RespondError(w, r, ErrorInfo{
+					Code:    http.StatusInternalServerError,
+					Message: "user has no path grants",
+				})
+				return
+			}
+
+			path, err := helpers.GetRequestedPath(r)
+			if err != nil {
+				cfg.responder.RespondError(w, r, ErrorInfo{
+					Code:    http.StatusInternalServerError,
+					Message: "failed to resolve the requested path",
+				})
+				return
+			}
+
+			matches := matchGrants(grants, path)
+			allowed, params := evaluateAccess(matches, r.Method)
+			if !allowed {
+				cfg.responder.RespondError(w, r, ErrorInfo{
+					Code:           http.StatusUnauthorized,
+					Message:        "not authorized for this path and method",
+					Path:           path,
+					RequiredAccess: allowedMethods(matches),
+				})
+				return
+			}
+
+			if len(params) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), pathParamsKey, params))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// errorDetails extracts the status code and message the middleware should
+// report for err, defaulting to 500 for anything that isn't an *Error.
+func errorDetails(err error) (code int, message string) {
+	if ferr, ok := err.(*Error); ok {
+		return ferr.Code, ferr.Message
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// matchedGrant is a PathGrant whose Path matched a request, along with any
+// named parameters it captured and how specific the match was.
+type matchedGrant struct {
+	grant  PathGrant
+	params map[string]string
+	spec   matchSpecificity
+}
+
+// matchGrants returns every grant among grants whose Path matches path.
+func matchGrants(grants []PathGrant, path string) []matchedGrant {
+	reqSegments := splitSegments(path)
+
+	var matches []matchedGrant
+	for _, g := range grants {
+		matched, params, spec := compilePattern(g.Path).match(reqSegments)
+		if !matched {
+			continue
+		}
+		matches = append(matches, matchedGrant{grant: g, params: params, spec: spec})
+	}
+	return matches
+}
+
+// evaluateAccess decides whether method is authorized given matches,
+// applying deny-wins-on-ties precedence: among the matches that cover
+// method, the most specific allow grant wins unless an equally or more
+// specific deny grant also covers it. It returns the named parameters
+// captured by the winning allow grant.
+func evaluateAccess(matches []matchedGrant, method string) (bool, map[string]string) {
+	var bestAllow, bestDeny *matchedGrant
+
+	for i := range matches {
+		m := &matches[i]
+		if !m.grant.Access.allows(method) {
+			continue
+		}
+		if m.grant.Deny {
+			if bestDeny == nil || m.spec.moreSpecificThan(bestDeny.spec) {
+				bestDeny = m
+			}
+		} else if bestAllow == nil || m.spec.moreSpecificThan(bestAllow.spec) {
+			bestAllow = m
+		}
+	}
+
+	if bestAllow == nil {
+		return false, nil
+	}
+	if bestDeny != nil && !bestAllow.spec.moreSpecificThan(bestDeny.spec) {
+		return false, nil
+	}
+	return true, bestAllow.params
+}
@@ -35,6 +35,20 @@ var (
 			Path:   "/hr/shipping/",
 		},
 	}
+
+	ShippingContractorGrants = []PathGrant{
+		PathGrant{
+			Access: Append,
+			Path:   "/hr/shipping/",
+		},
+	}
+
+	ShippingAuditorGrants = []PathGrant{
+		PathGrant{
+			Access: Admin,
+			Path:   "/hr/shipping/",
+		},
+	}
 )
 
 type mockHelpers struct{}
@@ -45,6 +59,10 @@ func (m mockHelpers) GetUserGrants(r *http.Request) ([]PathGrant, error) {
 		return ShippingManagerGrants, nil
 	} else if user == "worker" {
 		return ShippingGrants, nil
+	} else if user == "contractor" {
+		return ShippingContractorGrants, nil
+	} else if user == "auditor" {
+		return ShippingAuditorGrants, nil
 	}
 
 	return nil, &Error{
@@ -198,6 +216,42 @@ func TestMiddleware(t *testing.T) {
 			user:         "worker",
 			expectedCode: 401,
 		},
+		{
+			method:       "GET",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "contractor",
+			expectedCode: 200,
+		},
+		{
+			method:       "POST",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "contractor",
+			expectedCode: 200,
+		},
+		{
+			method:       "DELETE",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "contractor",
+			expectedCode: 401,
+		},
+		{
+			method:       "DELETE",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "auditor",
+			expectedCode: 200,
+		},
+		{
+			method:       "OPTIONS",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "auditor",
+			expectedCode: 200,
+		},
+		{
+			method:       "OPTIONS",
+			url:          "http://testing.com/hr/shipping/instructions.pdf",
+			user:         "manager",
+			expectedCode: 401,
+		},
 	}
 
 	hdlr := CreateFilePermissionsMiddleware(mockHelpers{})(defaultHandler{})
@@ -0,0 +1,149 @@
+package filepermissions
+
+import (
+	"context"
+	"strings"
+)
+
+type contextKey string
+
+// pathParamsKey is the context key under which named parameters captured
+// while matching a PathGrant (e.g. the "id" in "/users/:id/files") are
+// stored on the request context.
+const pathParamsKey contextKey = "filepermissions.pathParams"
+
+// PathParams returns the named path parameters captured by the grant that
+// authorized the current request, or nil if the matching grant captured
+// none.
+func PathParams(ctx context.Context) map[string]string {
+	params, _ := ctx.Value(pathParamsKey).(map[string]string)
+	return params
+}
+
+type segmentKind int
+
+const (
+	segLiteral segmentKind = iota
+	segParam
+	segWildcard
+)
+
+// patternSegment is one "/"-delimited component of a PathGrant.Path.
+type patternSegment struct {
+	kind  segmentKind
+	value string // literal text, or the param name with its leading ':' stripped
+}
+
+// compiledPattern is a PathGrant.Path broken into matchable segments.
+type compiledPattern struct {
+	segments []patternSegment
+	// directory is true when the pattern should also match anything
+	// nested below it, either because it was written with a trailing "/"
+	// (the historical directory-prefix style) or with an explicit
+	// trailing "*" catch-all.
+	directory bool
+}
+
+// compilePattern parses a PathGrant.Path into a compiledPattern. Supported
+// segment syntax: plain literals ("shipping"), named parameters
+// (":id"), and "*" wildcards. A "*" in the final position is a catch-all
+// that also matches everything nested below it, same as a trailing "/".
+func compilePattern(path string) compiledPattern {
+	trimmed := strings.Trim(path, "/")
+	directory := strings.HasSuffix(path, "/") && !strings.HasSuffix(trimmed, "*")
+	if trimmed == "" {
+		return compiledPattern{directory: directory}
+	}
+
+	parts := strings.Split(trimmed, "/")
+	segments := make([]patternSegment, 0, len(parts))
+	for i, part := range parts {
+		switch {
+		case part == "*":
+			segments = append(segments, patternSegment{kind: segWildcard})
+			if i == len(parts)-1 {
+				directory = true
+			}
+		case strings.HasPrefix(part, ":") && len(part) > 1:
+			segments = append(segments, patternSegment{kind: segParam, value: part[1:]})
+		default:
+			segments = append(segments, patternSegment{kind: segLiteral, value: part})
+		}
+	}
+	return compiledPattern{segments: segments, directory: directory}
+}
+
+// matchSpecificity ranks how specific a match was so the middleware can
+// prefer the most precise grant when several patterns match the same
+// request path.
+type matchSpecificity struct {
+	literals  int
+	wildcards int
+	length    int
+}
+
+// moreSpecificThan implements the grant precedence rules: more literal
+// segments wins, then a named parameter beats a wildcard, then the longer
+// pattern wins.
+func (a matchSpecificity) moreSpecificThan(b matchSpecificity) bool {
+	if a.literals != b.literals {
+		return a.literals > b.literals
+	}
+	if a.wildcards != b.wildcards {
+		return a.wildcards < b.wildcards
+	}
+	return a.length > b.length
+}
+
+// splitSegments breaks a request path into its "/"-delimited components,
+// ignoring leading, trailing, and duplicate slashes.
+func splitSegments(path string) []string {
+	var segments []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// match reports whether reqSegments satisfies p, returning any named
+// parameters it captured and how specific the match was.
+func (p compiledPattern) match(reqSegments []string) (bool, map[string]string, matchSpecificity) {
+	spec := matchSpecificity{length: len(p.segments)}
+	var params map[string]string
+
+	for i, seg := range p.segments {
+		last := i == len(p.segments)-1
+		if i >= len(reqSegments) {
+			if seg.kind == segWildcard && last {
+				spec.wildcards++
+				return true, params, spec
+			}
+			return false, nil, matchSpecificity{}
+		}
+
+		switch seg.kind {
+		case segLiteral:
+			if reqSegments[i] != seg.value {
+				return false, nil, matchSpecificity{}
+			}
+			spec.literals++
+		case segParam:
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[seg.value] = reqSegments[i]
+		case segWildcard:
+			spec.wildcards++
+			if last {
+				return true, params, spec
+			}
+		}
+	}
+
+	if len(reqSegments) > len(p.segments) && !p.directory {
+		return false, nil, matchSpecificity{}
+	}
+	return true, params, spec
+}
@@ -0,0 +1,101 @@
+package filepermissions
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompiledPatternMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name       string
+		pattern    string
+		path       string
+		wantMatch  bool
+		wantParams map[string]string
+	}{
+		{
+			name:      "directory prefix still matches nested files",
+			pattern:   "/hr/shipping/",
+			path:      "/hr/shipping/instructions.pdf",
+			wantMatch: true,
+		},
+		{
+			name:      "directory prefix does not match a sibling with a similar name",
+			pattern:   "/hr/shipping/",
+			path:      "/hr/shipping-secret/instructions.pdf",
+			wantMatch: false,
+		},
+		{
+			name:       "named parameter captures its segment",
+			pattern:    "/users/:id/files",
+			path:       "/users/42/files",
+			wantMatch:  true,
+			wantParams: map[string]string{"id": "42"},
+		},
+		{
+			name:      "named parameter pattern requires an exact segment count",
+			pattern:   "/users/:id/files",
+			path:      "/users/42/files/nested.txt",
+			wantMatch: false,
+		},
+		{
+			name:      "trailing catch-all matches everything below it",
+			pattern:   "/hr/*",
+			path:      "/hr/shipping/instructions.pdf",
+			wantMatch: true,
+		},
+		{
+			name:      "trailing catch-all matches the bare directory",
+			pattern:   "/hr/*",
+			path:      "/hr",
+			wantMatch: true,
+		},
+		{
+			name:      "mid-pattern glob matches exactly one segment",
+			pattern:   "/projects/*/reports/",
+			path:      "/projects/opal/reports/quarterly.pdf",
+			wantMatch: true,
+		},
+		{
+			name:      "mid-pattern glob does not skip a segment",
+			pattern:   "/projects/*/reports/",
+			path:      "/projects/opal/archive/reports/quarterly.pdf",
+			wantMatch: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, params, _ := compilePattern(tc.pattern).match(splitSegments(tc.path))
+			assert.Equal(tc.wantMatch, matched)
+			if tc.wantMatch && tc.wantParams != nil {
+				assert.Equal(tc.wantParams, params)
+			}
+		})
+	}
+}
+
+func TestMatchSpecificityPrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	grants := []PathGrant{
+		{Access: Read, Path: "/hr/*"},
+		{Access: ReadWrite, Path: "/hr/shipping/"},
+	}
+	matches := matchGrants(grants, "/hr/shipping/instructions.pdf")
+	assert.Len(matches, 2)
+	allowed, _ := evaluateAccess(matches, http.MethodDelete)
+	assert.True(allowed, "the more specific grant's ReadWrite access should be in the union")
+
+	grants = []PathGrant{
+		{Access: Read, Path: "/projects/*/reports/"},
+		{Access: ReadWrite, Path: "/projects/:id/reports/"},
+	}
+	matches = matchGrants(grants, "/projects/opal/reports/quarterly.pdf")
+	_, params := evaluateAccess(matches, http.MethodGet)
+	assert.Equal(map[string]string{"id": "opal"}, params, "the named parameter grant should win over the equivalent wildcard")
+}
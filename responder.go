@@ -0,0 +1,112 @@
+package filepermissions
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorInfo describes a denied request for an ErrorResponder to render.
+type ErrorInfo struct {
+	// Code is the HTTP status the response should carry.
+	Code int
+	// Message is a human-readable description of why the request was denied.
+	Message string
+	// Path is the file path the request targeted, when it was known at the
+	// time of the failure.
+	Path string
+	// RequiredAccess lists the HTTP methods that were permitted for Path,
+	// when a grant matched but didn't cover the request's method.
+	RequiredAccess []string
+}
+
+// ErrorResponder renders a denied request to the client. Register a custom
+// implementation with WithErrorResponder to change how errors are reported;
+// the default is jsonErrorResponder, which negotiates JSON, XML, or plain
+// text based on the request's Accept header.
+type ErrorResponder interface {
+	RespondError(w http.ResponseWriter, r *http.Request, info ErrorInfo)
+}
+
+// Option configures CreateFilePermissionsMiddleware.
+type Option func(*config)
+
+type config struct {
+	responder ErrorResponder
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{responder: jsonErrorResponder{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithErrorResponder overrides the default error responder, e.g. to render
+// denials in a format other than JSON/XML/plain text.
+func WithErrorResponder(responder ErrorResponder) Option {
+	return func(c *config) {
+		c.responder = responder
+	}
+}
+
+// jsonErrorResponder is the default ErrorResponder. It renders a body
+// shaped like the Error struct, negotiating JSON, XML, or plain text from
+// the request's Accept header; JSON is used when no preference is given.
+type jsonErrorResponder struct{}
+
+type errorPayload struct {
+	XMLName        xml.Name `xml:"error" json:"-"`
+	Code           int      `xml:"code" json:"code"`
+	Message        string   `xml:"message" json:"message"`
+	Path           string   `xml:"path,omitempty" json:"path,omitempty"`
+	RequiredAccess []string `xml:"requiredAccess>method,omitempty" json:"requiredAccess,omitempty"`
+}
+
+func (jsonErrorResponder) RespondError(w http.ResponseWriter, r *http.Request, info ErrorInfo) {
+	payload := errorPayload{
+		Code:           info.Code,
+		Message:        info.Message,
+		Path:           info.Path,
+		RequiredAccess: info.RequiredAccess,
+	}
+
+	switch negotiate(r.Header.Get("Accept")) {
+	case contentXML:
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(info.Code)
+		xml.NewEncoder(w).Encode(payload)
+	case contentText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(info.Code)
+		fmt.Fprintf(w, "%d %s", info.Code, info.Message)
+	default:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(info.Code)
+		json.NewEncoder(w).Encode(payload)
+	}
+}
+
+type contentType int
+
+const (
+	contentJSON contentType = iota
+	contentXML
+	contentText
+)
+
+// negotiate picks a response format from an Accept header, defaulting to
+// JSON when the header is empty or names nothing we support.
+func negotiate(accept string) contentType {
+	switch {
+	case strings.Contains(accept, "application/xml"), strings.Contains(accept, "text/xml"):
+		return contentXML
+	case strings.Contains(accept, "text/plain"):
+		return contentText
+	default:
+		return contentJSON
+	}
+}
@@ -0,0 +1,90 @@
+package filepermissions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONErrorResponderContentNegotiation(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+		expectedBodyPrefix  string
+	}{
+		{
+			name:                "defaults to JSON",
+			accept:              "",
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBodyPrefix:  `{"code":401`,
+		},
+		{
+			name:                "honors an XML Accept header",
+			accept:              "application/xml",
+			expectedContentType: "application/xml; charset=utf-8",
+			expectedBodyPrefix:  `<error>`,
+		},
+		{
+			name:                "honors a plain text Accept header",
+			accept:              "text/plain",
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBodyPrefix:  "401 not authorized",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://testing.com/hr/salaries/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			res := httptest.NewRecorder()
+
+			jsonErrorResponder{}.RespondError(res, req, ErrorInfo{
+				Code:           401,
+				Message:        "not authorized",
+				Path:           "/hr/salaries/",
+				RequiredAccess: []string{"GET"},
+			})
+
+			assert.Equal(401, res.Code)
+			assert.Equal(tc.expectedContentType, res.Header().Get("Content-Type"))
+			assert.True(strings.HasPrefix(res.Body.String(), tc.expectedBodyPrefix), res.Body.String())
+		})
+	}
+}
+
+type recordingResponder struct {
+	infos *[]ErrorInfo
+}
+
+func (r recordingResponder) RespondError(w http.ResponseWriter, _ *http.Request, info ErrorInfo) {
+	*r.infos = append(*r.infos, info)
+	w.WriteHeader(info.Code)
+}
+
+func TestWithErrorResponderOption(t *testing.T) {
+	assert := assert.New(t)
+
+	var infos []ErrorInfo
+	hdlr := CreateFilePermissionsMiddleware(mockHelpers{}, WithErrorResponder(recordingResponder{infos: &infos}))(defaultHandler{})
+
+	req := httptest.NewRequest("GET", "http://testing.com/hr/salaries/instructions.pdf", nil)
+	ctx := context.WithValue(req.Context(), userKey, "worker")
+	req = req.WithContext(ctx)
+	res := httptest.NewRecorder()
+	hdlr.ServeHTTP(res, req)
+
+	assert.Equal(http.StatusUnauthorized, res.Code)
+	if assert.Len(infos, 1) {
+		assert.Equal(http.StatusUnauthorized, infos[0].Code)
+		assert.Equal("/hr/salaries/instructions.pdf", infos[0].Path)
+	}
+}